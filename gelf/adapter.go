@@ -0,0 +1,36 @@
+package gelf
+
+import (
+	"io"
+	"runtime"
+)
+
+// WriterAdapter adapts a Writer to the io.Writer interface, so it can be
+// used as the output of the standard library's log package or anything
+// else that writes plain text lines.
+type WriterAdapter struct {
+	w        Writer
+	facility string
+	hostname string
+}
+
+// NewWriterAdapter returns an io.Writer that turns each Write call into a
+// single GELF message sent through w.
+func NewWriterAdapter(w Writer, facility, hostname string) io.Writer {
+	return &WriterAdapter{w: w, facility: facility, hostname: hostname}
+}
+
+// Write implements io.Writer, constructing a Message from p the same way
+// gelf.Writer.Write does and sending it through the underlying Writer.
+func (a *WriterAdapter) Write(p []byte) (int, error) {
+	_, file, line, ok := runtime.Caller(1)
+	if !ok {
+		file = ""
+		line = 0
+	}
+	m := constructMessage(p, a.hostname, a.facility, file, line)
+	if err := a.w.WriteMessage(m); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
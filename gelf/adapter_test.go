@@ -0,0 +1,29 @@
+package gelf
+
+import "testing"
+
+// TestWriterAdapterSetsHostAndFacility checks that WriterAdapter stamps
+// the configured hostname/facility onto every Message it builds.
+func TestWriterAdapterSetsHostAndFacility(t *testing.T) {
+	cw := &captureWriter{}
+	adapter := NewWriterAdapter(cw, "myapp", "myhost")
+
+	if _, err := adapter.Write([]byte("hello\n")); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+
+	if len(cw.messages) != 1 {
+		t.Fatalf("got %d messages, want 1", len(cw.messages))
+	}
+	m := cw.messages[0]
+
+	if m.Host != "myhost" {
+		t.Errorf("Host = %q, want %q", m.Host, "myhost")
+	}
+	if m.Facility != "myapp" {
+		t.Errorf("Facility = %q, want %q", m.Facility, "myapp")
+	}
+	if m.Short != "hello" {
+		t.Errorf("Short = %q, want %q", m.Short, "hello")
+	}
+}
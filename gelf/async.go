@@ -0,0 +1,219 @@
+package gelf
+
+import (
+	"errors"
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+// defaultAsyncQueueSize is the queue capacity used when
+// AsyncOptions.QueueSize is zero.
+const defaultAsyncQueueSize = 1024
+
+// OverflowPolicy controls what an AsyncWriter does when its queue is
+// full.
+type OverflowPolicy int
+
+const (
+	// BlockOnFull blocks WriteMessage until the queue has room.
+	BlockOnFull OverflowPolicy = iota
+
+	// DropOldest discards the oldest queued message to make room for the
+	// new one.
+	DropOldest
+
+	// DropNewest discards the message being written, leaving the queue
+	// unchanged.
+	DropNewest
+)
+
+// AsyncOptions configures an AsyncWriter.
+type AsyncOptions struct {
+	// QueueSize is the number of messages the queue can hold before
+	// Overflow takes effect. Defaults to defaultAsyncQueueSize.
+	QueueSize int
+
+	// Overflow selects the behavior applied once the queue is full.
+	Overflow OverflowPolicy
+}
+
+// AsyncStats reports an AsyncWriter's cumulative counters.
+type AsyncStats struct {
+	// Enqueued is the number of messages accepted onto the queue.
+	Enqueued uint64
+	// Dropped is the number of messages discarded by the overflow
+	// policy.
+	Dropped uint64
+	// Flushed is the number of messages successfully sent to the
+	// underlying Writer.
+	Flushed uint64
+	// Failed is the number of messages the underlying Writer's
+	// WriteMessage rejected or failed to send.
+	Failed uint64
+}
+
+// ErrAsyncWriterClosed is returned by WriteMessage once the AsyncWriter
+// has been closed.
+var ErrAsyncWriterClosed = errors.New("gelf: async writer is closed")
+
+// AsyncWriter wraps a Writer with a bounded, in-memory queue flushed by a
+// background goroutine, so WriteMessage returns without waiting on
+// network I/O. It is intended for bursty, high-volume emit paths such as
+// a container log driver.
+type AsyncWriter struct {
+	inner    Writer
+	overflow OverflowPolicy
+	queue    chan *Message
+	done     chan struct{}
+	wg       sync.WaitGroup
+
+	closeOnce sync.Once
+
+	enqueued uint64
+	dropped  uint64
+	flushed  uint64
+	failed   uint64
+}
+
+// NewAsyncWriter returns an AsyncWriter that queues messages and sends
+// them to inner from a background goroutine.
+func NewAsyncWriter(inner Writer, opts AsyncOptions) *AsyncWriter {
+	queueSize := opts.QueueSize
+	if queueSize <= 0 {
+		queueSize = defaultAsyncQueueSize
+	}
+
+	a := &AsyncWriter{
+		inner:    inner,
+		overflow: opts.Overflow,
+		queue:    make(chan *Message, queueSize),
+		done:     make(chan struct{}),
+	}
+
+	a.wg.Add(1)
+	go a.run()
+
+	return a
+}
+
+// Write implements io.Writer by enqueueing a Message built the same way
+// gelf.Writer.Write would, reusing the inner Writer's hostname and
+// facility if it exposes them.
+func (a *AsyncWriter) Write(p []byte) (int, error) {
+	var hostname, facility string
+	if hf, ok := a.inner.(hostFacilitier); ok {
+		hostname, facility = hf.hostFacility()
+	}
+
+	_, file, line, ok := runtime.Caller(1)
+	if !ok {
+		file = ""
+		line = 0
+	}
+
+	if err := a.WriteMessage(constructMessage(p, hostname, facility, file, line)); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// WriteMessage enqueues m for delivery by the background goroutine,
+// applying the configured OverflowPolicy if the queue is full.
+func (a *AsyncWriter) WriteMessage(m *Message) error {
+	select {
+	case <-a.done:
+		return ErrAsyncWriterClosed
+	default:
+	}
+
+	switch a.overflow {
+	case DropNewest:
+		select {
+		case a.queue <- m:
+			atomic.AddUint64(&a.enqueued, 1)
+		default:
+			atomic.AddUint64(&a.dropped, 1)
+		}
+		return nil
+
+	case DropOldest:
+		for {
+			select {
+			case a.queue <- m:
+				atomic.AddUint64(&a.enqueued, 1)
+				return nil
+			default:
+			}
+			select {
+			case <-a.queue:
+				atomic.AddUint64(&a.dropped, 1)
+			default:
+			}
+		}
+
+	default: // BlockOnFull
+		select {
+		case a.queue <- m:
+			atomic.AddUint64(&a.enqueued, 1)
+			return nil
+		case <-a.done:
+			return ErrAsyncWriterClosed
+		}
+	}
+}
+
+// run drains the queue and sends each message to the underlying Writer
+// until Close is called, then flushes whatever is left before exiting.
+func (a *AsyncWriter) run() {
+	defer a.wg.Done()
+	for {
+		select {
+		case m := <-a.queue:
+			a.send(m)
+		case <-a.done:
+			a.drain()
+			return
+		}
+	}
+}
+
+// drain flushes any messages left in the queue without blocking.
+func (a *AsyncWriter) drain() {
+	for {
+		select {
+		case m := <-a.queue:
+			a.send(m)
+		default:
+			return
+		}
+	}
+}
+
+func (a *AsyncWriter) send(m *Message) {
+	if err := a.inner.WriteMessage(m); err != nil {
+		atomic.AddUint64(&a.failed, 1)
+		return
+	}
+	atomic.AddUint64(&a.flushed, 1)
+}
+
+// Stats returns a snapshot of the AsyncWriter's cumulative counters.
+func (a *AsyncWriter) Stats() AsyncStats {
+	return AsyncStats{
+		Enqueued: atomic.LoadUint64(&a.enqueued),
+		Dropped:  atomic.LoadUint64(&a.dropped),
+		Flushed:  atomic.LoadUint64(&a.flushed),
+		Failed:   atomic.LoadUint64(&a.failed),
+	}
+}
+
+// Close stops accepting new messages, waits for the queue to drain, and
+// closes the underlying Writer.
+func (a *AsyncWriter) Close() error {
+	a.closeOnce.Do(func() {
+		close(a.done)
+	})
+	a.wg.Wait()
+	return a.inner.Close()
+}
@@ -0,0 +1,226 @@
+package gelf
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+// blockingCaptureWriter is a Writer whose WriteMessage blocks until
+// release is closed, letting tests deterministically control when the
+// AsyncWriter's background goroutine is busy vs. idle.
+type blockingCaptureWriter struct {
+	captureWriter
+	entered chan struct{}
+	release chan struct{}
+}
+
+func newBlockingCaptureWriter() *blockingCaptureWriter {
+	return &blockingCaptureWriter{
+		entered: make(chan struct{}, 1),
+		release: make(chan struct{}),
+	}
+}
+
+func (w *blockingCaptureWriter) WriteMessage(m *Message) error {
+	select {
+	case w.entered <- struct{}{}:
+	default:
+	}
+	<-w.release
+	return w.captureWriter.WriteMessage(m)
+}
+
+// erroringWriter always fails WriteMessage, for testing AsyncStats.Failed.
+type erroringWriter struct{}
+
+func (erroringWriter) Write(p []byte) (int, error)   { return len(p), nil }
+func (erroringWriter) WriteMessage(m *Message) error { return errors.New("boom") }
+func (erroringWriter) Close() error                  { return nil }
+
+// hostFacilityCaptureWriter is a captureWriter that also implements
+// hostFacilitier, for testing that AsyncWriter.Write reuses it.
+type hostFacilityCaptureWriter struct {
+	captureWriter
+	hostname, facility string
+}
+
+func (w *hostFacilityCaptureWriter) hostFacility() (string, string) {
+	return w.hostname, w.facility
+}
+
+// waitForStats polls a.Stats() until ok reports true or the deadline
+// passes.
+func waitForStats(t *testing.T, a *AsyncWriter, ok func(AsyncStats) bool) AsyncStats {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		s := a.Stats()
+		if ok(s) {
+			return s
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatalf("Stats() did not reach expected state in time, last seen: %+v", a.Stats())
+	return AsyncStats{}
+}
+
+// TestAsyncWriterBlockOnFull checks that WriteMessage blocks, rather than
+// dropping or erroring, once the queue is full under BlockOnFull.
+func TestAsyncWriterBlockOnFull(t *testing.T) {
+	inner := newBlockingCaptureWriter()
+	a := NewAsyncWriter(inner, AsyncOptions{QueueSize: 1, Overflow: BlockOnFull})
+
+	if err := a.WriteMessage(constructMessageFromString("m0", LOG_INFO, nil)); err != nil {
+		t.Fatalf("WriteMessage(m0): %v", err)
+	}
+	<-inner.entered // m0 is now in-flight inside inner.WriteMessage; queue is empty.
+
+	if err := a.WriteMessage(constructMessageFromString("m1", LOG_INFO, nil)); err != nil {
+		t.Fatalf("WriteMessage(m1): %v", err)
+	}
+	// Queue (capacity 1) now holds m1 and is full.
+
+	done := make(chan error, 1)
+	go func() {
+		done <- a.WriteMessage(constructMessageFromString("m2", LOG_INFO, nil))
+	}()
+
+	select {
+	case err := <-done:
+		t.Fatalf("WriteMessage(m2) returned early (err=%v), want it to block while the queue is full", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	close(inner.release) // unblocks m0, then m1, freeing room for m2.
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("WriteMessage(m2): %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("WriteMessage(m2) still blocked after queue drained")
+	}
+
+	if err := a.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+}
+
+// TestAsyncWriterDropNewest checks that DropNewest discards the message
+// being written once the queue is full, leaving the queue unchanged.
+func TestAsyncWriterDropNewest(t *testing.T) {
+	inner := newBlockingCaptureWriter()
+	a := NewAsyncWriter(inner, AsyncOptions{QueueSize: 1, Overflow: DropNewest})
+	defer func() {
+		close(inner.release)
+		a.Close()
+	}()
+
+	if err := a.WriteMessage(constructMessageFromString("m0", LOG_INFO, nil)); err != nil {
+		t.Fatalf("WriteMessage(m0): %v", err)
+	}
+	<-inner.entered // m0 in-flight; queue empty.
+
+	if err := a.WriteMessage(constructMessageFromString("m1", LOG_INFO, nil)); err != nil {
+		t.Fatalf("WriteMessage(m1): %v", err)
+	}
+	// Queue (capacity 1) now holds m1 and is full.
+
+	if err := a.WriteMessage(constructMessageFromString("m2", LOG_INFO, nil)); err != nil {
+		t.Fatalf("WriteMessage(m2): %v", err)
+	}
+
+	stats := a.Stats()
+	if stats.Enqueued != 2 {
+		t.Errorf("Enqueued = %d, want 2", stats.Enqueued)
+	}
+	if stats.Dropped != 1 {
+		t.Errorf("Dropped = %d, want 1", stats.Dropped)
+	}
+}
+
+// TestAsyncWriterDropOldest checks that DropOldest evicts the oldest
+// queued message to make room once the queue is full.
+func TestAsyncWriterDropOldest(t *testing.T) {
+	inner := newBlockingCaptureWriter()
+	a := NewAsyncWriter(inner, AsyncOptions{QueueSize: 2, Overflow: DropOldest})
+	defer a.Close()
+
+	msgs := make([]*Message, 4)
+	for i := range msgs {
+		msgs[i] = constructMessageFromString(fmt.Sprintf("m%d", i), LOG_INFO, nil)
+	}
+
+	if err := a.WriteMessage(msgs[0]); err != nil {
+		t.Fatalf("WriteMessage(m0): %v", err)
+	}
+	<-inner.entered // m0 in-flight; queue empty, capacity 2.
+
+	for i := 1; i <= 3; i++ {
+		if err := a.WriteMessage(msgs[i]); err != nil {
+			t.Fatalf("WriteMessage(m%d): %v", i, err)
+		}
+	}
+	// m1 and m2 filled the queue; m3 should have evicted m1.
+
+	stats := a.Stats()
+	if stats.Enqueued != 4 {
+		t.Errorf("Enqueued = %d, want 4", stats.Enqueued)
+	}
+	if stats.Dropped != 1 {
+		t.Errorf("Dropped = %d, want 1", stats.Dropped)
+	}
+
+	close(inner.release)
+	waitForStats(t, a, func(s AsyncStats) bool { return s.Flushed == 3 })
+
+	got := inner.messageSnapshot()
+	if len(got) != 3 {
+		t.Fatalf("got %d delivered messages, want 3", len(got))
+	}
+	if got[0].Short != "m0" || got[1].Short != "m2" || got[2].Short != "m3" {
+		t.Fatalf("delivered messages = %v, want [m0 m2 m3]", []string{got[0].Short, got[1].Short, got[2].Short})
+	}
+}
+
+// TestAsyncWriterStatsFailed checks that a send failure is reflected in
+// Stats().Failed, distinct from overflow drops.
+func TestAsyncWriterStatsFailed(t *testing.T) {
+	a := NewAsyncWriter(erroringWriter{}, AsyncOptions{})
+	defer a.Close()
+
+	if err := a.WriteMessage(constructMessageFromString("boom", LOG_INFO, nil)); err != nil {
+		t.Fatalf("WriteMessage: %v", err)
+	}
+
+	stats := waitForStats(t, a, func(s AsyncStats) bool { return s.Failed == 1 })
+	if stats.Dropped != 0 {
+		t.Errorf("Dropped = %d, want 0", stats.Dropped)
+	}
+}
+
+// TestAsyncWriterWritePropagatesHostAndFacility checks that Write builds
+// its Message using the inner Writer's hostname/facility rather than
+// leaving them blank.
+func TestAsyncWriterWritePropagatesHostAndFacility(t *testing.T) {
+	inner := &hostFacilityCaptureWriter{hostname: "myhost", facility: "myapp"}
+	a := NewAsyncWriter(inner, AsyncOptions{})
+	defer a.Close()
+
+	if _, err := a.Write([]byte("hello\n")); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+
+	waitForStats(t, a, func(s AsyncStats) bool { return s.Flushed == 1 })
+
+	got := inner.messageSnapshot()
+	if len(got) != 1 {
+		t.Fatalf("got %d messages, want 1", len(got))
+	}
+	if got[0].Host != "myhost" || got[0].Facility != "myapp" {
+		t.Errorf("Host/Facility = %q/%q, want myhost/myapp", got[0].Host, got[0].Facility)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
+	"regexp"
 	"strings"
 	"time"
 )
@@ -15,7 +16,7 @@ type Message struct {
 	Host     string                 `json:"host,omitempty"`
 	Short    string                 `json:"short_message"`
 	Full     string                 `json:"full_message,omitempty"`
-	TimeUnix int64                  `json:"timestamp"`
+	TimeUnix float64                `json:"timestamp"`
 	Level    int32                  `json:"level,omitempty"`
 	Facility string                 `json:"facility,omitempty"`
 	Extra    map[string]interface{} `json:"-"`
@@ -34,7 +35,37 @@ const (
 	LOG_DEBUG
 )
 
+// extraKeyPattern matches the GELF spec's allowed characters for
+// additional field names: an underscore followed by word characters,
+// dots or hyphens.
+var extraKeyPattern = regexp.MustCompile(`^_[\w\.\-]*$`)
+
+// InvalidExtraKeyError is returned by MarshalJSONBuf when m.Extra
+// contains a key that Graylog would silently drop.
+type InvalidExtraKeyError struct {
+	Key string
+}
+
+func (e *InvalidExtraKeyError) Error() string {
+	return fmt.Sprintf("gelf: invalid extra field key %q: must match %s and must not be \"_id\"", e.Key, extraKeyPattern.String())
+}
+
+// validateExtraKeys checks that every key in extra is a legal GELF
+// additional field name.
+func validateExtraKeys(extra map[string]interface{}) error {
+	for k := range extra {
+		if k == "_id" || !extraKeyPattern.MatchString(k) {
+			return &InvalidExtraKeyError{Key: k}
+		}
+	}
+	return nil
+}
+
 func (m *Message) MarshalJSONBuf(buf *bytes.Buffer) error {
+	if err := validateExtraKeys(m.Extra); err != nil {
+		return err
+	}
+
 	b, err := json.Marshal(m)
 	if err != nil {
 		return err
@@ -98,7 +129,7 @@ func (m *Message) UnmarshalJSON(data []byte) error {
 		case "full_message":
 			m.Full, ok = v.(string)
 		case "timestamp":
-			m.TimeUnix, ok = v.(int64)
+			m.TimeUnix, ok = v.(float64)
 		case "level":
 			var level float64
 			level, ok = v.(float64)
@@ -114,6 +145,14 @@ func (m *Message) UnmarshalJSON(data []byte) error {
 	return nil
 }
 
+// nowUnix returns the current time as floating-point seconds since the
+// epoch, derived from UnixNano so it keeps sub-second precision (finer
+// than the milliseconds the GELF 1.1 "timestamp" field needs); the value
+// is not rounded to whole milliseconds.
+func nowUnix() float64 {
+	return float64(time.Now().UnixNano()) / float64(time.Second)
+}
+
 func (m *Message) toBytes(buf *bytes.Buffer) (messageBytes []byte, err error) {
 	if err = m.MarshalJSONBuf(buf); err != nil {
 		return nil, err
@@ -142,7 +181,7 @@ func constructMessage(p []byte, hostname string, facility string, file string, l
 		Host:     hostname,
 		Short:    string(short),
 		Full:     string(full),
-		TimeUnix: time.Now().Unix(),
+		TimeUnix: nowUnix(),
 		Level:    6, // info
 		Facility: facility,
 		Extra: map[string]interface{}{
@@ -161,7 +200,7 @@ func constructMessageFromString(message string, level int32, extra map[string]in
 	m = &Message{
 		Version:  "1.1",
 		Short:    message,
-		TimeUnix: time.Now().Unix(),
+		TimeUnix: nowUnix(),
 		Level:    level,
 		Extra:    extra,
 	}
@@ -0,0 +1,84 @@
+package gelf
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"math"
+	"testing"
+	"time"
+)
+
+// TestTimestampMillisecondPrecision checks that a sub-second TimeUnix
+// survives a MarshalJSONBuf/UnmarshalJSON round trip, as required by
+// GELF 1.1's floating-point "timestamp" field.
+func TestTimestampMillisecondPrecision(t *testing.T) {
+	m := &Message{
+		Version:  "1.1",
+		Short:    "test",
+		TimeUnix: 1732564821.123,
+	}
+
+	var buf bytes.Buffer
+	if err := m.MarshalJSONBuf(&buf); err != nil {
+		t.Fatalf("MarshalJSONBuf: %v", err)
+	}
+
+	got := new(Message)
+	if err := json.Unmarshal(buf.Bytes(), got); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+
+	if diff := math.Abs(got.TimeUnix - m.TimeUnix); diff > 1e-6 {
+		t.Fatalf("TimeUnix = %v, want %v", got.TimeUnix, m.TimeUnix)
+	}
+}
+
+// TestValidateExtraKeys checks the GELF additional-field key pattern,
+// including the reserved "_id" key that the pattern alone would allow.
+func TestValidateExtraKeys(t *testing.T) {
+	valid := []string{"_file", "_line", "_my.key-1"}
+	for _, k := range valid {
+		if err := validateExtraKeys(map[string]interface{}{k: 1}); err != nil {
+			t.Errorf("validateExtraKeys(%q): unexpected error: %v", k, err)
+		}
+	}
+
+	invalid := []string{"_id", "no_leading_underscore", "_bad key"}
+	for _, k := range invalid {
+		err := validateExtraKeys(map[string]interface{}{k: 1})
+		var target *InvalidExtraKeyError
+		if !errors.As(err, &target) {
+			t.Errorf("validateExtraKeys(%q): error = %v, want *InvalidExtraKeyError", k, err)
+		}
+	}
+}
+
+// TestMarshalJSONBufRejectsInvalidExtraKey checks that MarshalJSONBuf
+// refuses to serialize a message with a reserved extra field key.
+func TestMarshalJSONBufRejectsInvalidExtraKey(t *testing.T) {
+	m := &Message{
+		Version: "1.1",
+		Short:   "test",
+		Extra:   map[string]interface{}{"_id": "nope"},
+	}
+
+	var buf bytes.Buffer
+	if err := m.MarshalJSONBuf(&buf); err == nil {
+		t.Fatal("MarshalJSONBuf: expected error for reserved \"_id\" key, got nil")
+	}
+}
+
+// TestNowUnixMatchesWallClock checks that nowUnix reports the current
+// time as float seconds, bracketed by two time.Now() calls.
+func TestNowUnixMatchesWallClock(t *testing.T) {
+	before := time.Now()
+	ts := nowUnix()
+	after := time.Now()
+
+	lo := float64(before.UnixNano()) / float64(time.Second)
+	hi := float64(after.UnixNano()) / float64(time.Second)
+	if ts < lo || ts > hi {
+		t.Fatalf("nowUnix() = %v, want value in [%v, %v]", ts, lo, hi)
+	}
+}
@@ -0,0 +1,201 @@
+package gelf
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"time"
+)
+
+// defaultChunkAssemblyTimeout is how long a Reader waits for the
+// remaining chunks of a partially received message before discarding
+// them.
+const defaultChunkAssemblyTimeout = 5 * time.Second
+
+// Reader listens for GELF messages on a UDP socket, reassembling chunked
+// datagrams as needed.
+type Reader struct {
+	conn *net.UDPConn
+
+	// ChunkAssemblyTimeout is how long to wait for all chunks of a
+	// message to arrive before evicting it. Defaults to
+	// defaultChunkAssemblyTimeout.
+	ChunkAssemblyTimeout time.Duration
+
+	mu     sync.Mutex
+	chunks map[string]*partialMessage
+}
+
+// partialMessage tracks the chunks received so far for a single chunked
+// message.
+type partialMessage struct {
+	total    byte
+	received int
+	chunks   [][]byte
+	deadline time.Time
+}
+
+// NewReader returns a new Reader listening on addr.
+func NewReader(addr string) (*Reader, error) {
+	udpAddr, err := net.ResolveUDPAddr("udp", addr)
+	if err != nil {
+		return nil, err
+	}
+	conn, err := net.ListenUDP("udp", udpAddr)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Reader{
+		conn:                 conn,
+		ChunkAssemblyTimeout: defaultChunkAssemblyTimeout,
+		chunks:               make(map[string]*partialMessage),
+	}, nil
+}
+
+// Addr returns the address the Reader is listening on.
+func (r *Reader) Addr() string {
+	return r.conn.LocalAddr().String()
+}
+
+// Close closes the underlying UDP socket.
+func (r *Reader) Close() error {
+	return r.conn.Close()
+}
+
+// ReadMessage reads a single datagram, reassembling chunks until a
+// complete message is available, and returns the decoded Message.
+func (r *Reader) ReadMessage() (*Message, error) {
+	buf := make([]byte, 1<<16)
+	for {
+		n, err := r.conn.Read(buf)
+		if err != nil {
+			return nil, err
+		}
+
+		r.evictExpired()
+
+		payload, err := r.assemble(buf[:n])
+		if err != nil {
+			return nil, err
+		}
+		if payload == nil {
+			// Message isn't complete yet; wait for more chunks.
+			continue
+		}
+
+		data, err := decompress(payload)
+		if err != nil {
+			return nil, err
+		}
+
+		m := new(Message)
+		if err := json.Unmarshal(data, m); err != nil {
+			return nil, err
+		}
+		return m, nil
+	}
+}
+
+// assemble feeds a single datagram into the chunk reassembly state and
+// returns the full payload once every chunk has arrived, or nil if more
+// chunks are still expected.
+func (r *Reader) assemble(datagram []byte) ([]byte, error) {
+	if len(datagram) < 2 || datagram[0] != chunkMagicByte0 || datagram[1] != chunkMagicByte1 {
+		// Not chunked; the whole datagram is the message.
+		return datagram, nil
+	}
+
+	if len(datagram) < chunkHeaderLen {
+		return nil, fmt.Errorf("gelf: chunked datagram too short: %d bytes", len(datagram))
+	}
+
+	msgID := string(datagram[2:10])
+	seq := datagram[10]
+	total := datagram[11]
+	payload := datagram[chunkHeaderLen:]
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	pm, ok := r.chunks[msgID]
+	if !ok {
+		pm = &partialMessage{
+			total:    total,
+			chunks:   make([][]byte, total),
+			deadline: time.Now().Add(r.timeout()),
+		}
+		r.chunks[msgID] = pm
+	}
+
+	if int(seq) >= len(pm.chunks) {
+		return nil, fmt.Errorf("gelf: chunk sequence %d out of range for %d total chunks", seq, pm.total)
+	}
+	if pm.chunks[seq] == nil {
+		pm.chunks[seq] = append([]byte(nil), payload...)
+		pm.received++
+	}
+
+	if pm.received < int(pm.total) {
+		return nil, nil
+	}
+
+	delete(r.chunks, msgID)
+
+	var full []byte
+	for _, c := range pm.chunks {
+		full = append(full, c...)
+	}
+	return full, nil
+}
+
+func (r *Reader) timeout() time.Duration {
+	if r.ChunkAssemblyTimeout <= 0 {
+		return defaultChunkAssemblyTimeout
+	}
+	return r.ChunkAssemblyTimeout
+}
+
+// evictExpired drops any partially received messages whose assembly
+// timeout has passed.
+func (r *Reader) evictExpired() {
+	now := time.Now()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for id, pm := range r.chunks {
+		if now.After(pm.deadline) {
+			delete(r.chunks, id)
+		}
+	}
+}
+
+// decompress auto-detects gzip or zlib magic bytes and decompresses
+// payload accordingly. Unrecognized magic bytes are treated as
+// uncompressed JSON, matching the Graylog server's own behavior.
+func decompress(payload []byte) ([]byte, error) {
+	switch {
+	case len(payload) >= 2 && payload[0] == 0x1f && payload[1] == 0x8b:
+		zr, err := gzip.NewReader(bytes.NewReader(payload))
+		if err != nil {
+			return nil, err
+		}
+		defer zr.Close()
+		return io.ReadAll(zr)
+	case len(payload) >= 2 && payload[0] == 0x78:
+		zr, err := zlib.NewReader(bytes.NewReader(payload))
+		if err != nil {
+			return nil, err
+		}
+		defer zr.Close()
+		return io.ReadAll(zr)
+	default:
+		return payload, nil
+	}
+}
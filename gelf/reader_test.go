@@ -0,0 +1,125 @@
+package gelf
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+	"time"
+)
+
+// randomString returns a pseudo-random, poorly-compressible string of n
+// bytes so that tests forcing chunking via a small ChunkSize actually
+// exercise it regardless of CompressionType.
+func randomString(n int) string {
+	const alphabet = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+	r := rand.New(rand.NewSource(42))
+	b := make([]byte, n)
+	for i := range b {
+		b[i] = alphabet[r.Intn(len(alphabet))]
+	}
+	return string(b)
+}
+
+// TestReaderRoundTrip sends messages through a UDPWriter to a Reader
+// listening on the same host, covering both a single datagram and a
+// message large enough to require chunking, for every CompressionType.
+func TestReaderRoundTrip(t *testing.T) {
+	reader, err := NewReader("127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("NewReader: %v", err)
+	}
+	defer reader.Close()
+
+	writer, err := NewUDPWriter(reader.Addr())
+	if err != nil {
+		t.Fatalf("NewUDPWriter: %v", err)
+	}
+	defer writer.Close()
+
+	cases := []struct {
+		name      string
+		short     string
+		chunkSize int
+	}{
+		{name: "single datagram", short: "hello gelf", chunkSize: defaultChunkSize},
+		{name: "chunked", short: randomString(5000), chunkSize: 100},
+	}
+
+	for _, compression := range []CompressionType{CompressGzip, CompressZlib, CompressNone} {
+		for _, tc := range cases {
+			t.Run(fmt.Sprintf("compression=%d/%s", compression, tc.name), func(t *testing.T) {
+				writer.CompressionType = compression
+				writer.ChunkSize = tc.chunkSize
+
+				sent := constructMessageFromString(tc.short, LOG_INFO, nil)
+				if err := writer.WriteMessage(sent); err != nil {
+					t.Fatalf("WriteMessage: %v", err)
+				}
+
+				got, err := reader.ReadMessage()
+				if err != nil {
+					t.Fatalf("ReadMessage: %v", err)
+				}
+				if got.Short != sent.Short {
+					t.Fatalf("Short = %q, want %q", got.Short, sent.Short)
+				}
+				if got.Version != sent.Version {
+					t.Fatalf("Version = %q, want %q", got.Version, sent.Version)
+				}
+			})
+		}
+	}
+}
+
+// buildChunk assembles a single GELF UDP chunk with the given message
+// ID, sequence number and total chunk count.
+func buildChunk(msgID []byte, seq, total byte, payload []byte) []byte {
+	chunk := make([]byte, 0, chunkHeaderLen+len(payload))
+	chunk = append(chunk, chunkMagicByte0, chunkMagicByte1)
+	chunk = append(chunk, msgID...)
+	chunk = append(chunk, seq, total)
+	chunk = append(chunk, payload...)
+	return chunk
+}
+
+// TestReaderEvictsExpiredPartialMessage checks that a partially received
+// chunked message is evicted once ChunkAssemblyTimeout passes, and that a
+// later message reusing the same ID isn't contaminated by the stale
+// chunks.
+func TestReaderEvictsExpiredPartialMessage(t *testing.T) {
+	r := &Reader{
+		ChunkAssemblyTimeout: 20 * time.Millisecond,
+		chunks:               make(map[string]*partialMessage),
+	}
+
+	msgID := []byte("12345678")
+
+	if payload, err := r.assemble(buildChunk(msgID, 0, 2, []byte("hello "))); err != nil {
+		t.Fatalf("assemble: %v", err)
+	} else if payload != nil {
+		t.Fatalf("assemble: got a complete payload from chunk 0 of 2")
+	}
+
+	if _, ok := r.chunks[string(msgID)]; !ok {
+		t.Fatal("expected a partial entry to be tracked after the first chunk")
+	}
+
+	time.Sleep(30 * time.Millisecond)
+	r.evictExpired()
+
+	if _, ok := r.chunks[string(msgID)]; ok {
+		t.Fatal("expected the partial entry to be evicted after ChunkAssemblyTimeout")
+	}
+
+	// A new message reusing the same ID should reconstruct cleanly; if
+	// eviction didn't clear the old entry, this single chunk would be
+	// (wrongly) treated as chunk 0 of the stale 2-chunk message and never
+	// complete.
+	payload, err := r.assemble(buildChunk(msgID, 0, 1, []byte("full payload")))
+	if err != nil {
+		t.Fatalf("assemble: %v", err)
+	}
+	if string(payload) != "full payload" {
+		t.Fatalf("payload = %q, want %q", payload, "full payload")
+	}
+}
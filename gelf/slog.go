@@ -0,0 +1,133 @@
+package gelf
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+	"time"
+)
+
+// boundAttrs remembers a batch of attributes passed to WithAttrs
+// together with the group path that was active at the time, so Handle
+// can flatten them under the right prefix regardless of any WithGroup
+// calls made afterwards.
+type boundAttrs struct {
+	groups []string
+	attrs  []slog.Attr
+}
+
+// Handler is a log/slog.Handler that sends records to a Writer as GELF
+// messages.
+type Handler struct {
+	w        Writer
+	facility string
+	hostname string
+	level    slog.Level
+	groups   []string
+	bound    []boundAttrs
+}
+
+// NewHandler returns a slog.Handler that sends records to w at or above
+// level, defaulting to slog.LevelInfo.
+func NewHandler(w Writer, facility, hostname string, level slog.Level) *Handler {
+	return &Handler{w: w, facility: facility, hostname: hostname, level: level}
+}
+
+// Enabled reports whether level is at or above the Handler's configured
+// level.
+func (h *Handler) Enabled(_ context.Context, level slog.Level) bool {
+	return level >= h.level
+}
+
+// Handle sends r to the underlying Writer as a single GELF message,
+// flowing r's attributes (and any attributes bound via WithAttrs) into
+// the message's Extra fields.
+func (h *Handler) Handle(_ context.Context, r slog.Record) error {
+	extra := make(map[string]interface{}, r.NumAttrs())
+	for _, b := range h.bound {
+		for _, a := range b.attrs {
+			addAttr(extra, b.groups, a)
+		}
+	}
+	r.Attrs(func(a slog.Attr) bool {
+		addAttr(extra, h.groups, a)
+		return true
+	})
+
+	m := &Message{
+		Version:  "1.1",
+		Host:     h.hostname,
+		Short:    r.Message,
+		TimeUnix: float64(r.Time.UnixNano()) / float64(time.Second),
+		Level:    slogLevelToSyslog(r.Level),
+		Facility: h.facility,
+		Extra:    extra,
+	}
+	return h.w.WriteMessage(m)
+}
+
+// WithAttrs returns a new Handler whose Extra fields will include attrs,
+// flattened under the group path active at this call, in addition to
+// those already bound.
+func (h *Handler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	if len(attrs) == 0 {
+		return h
+	}
+
+	newBound := make([]boundAttrs, len(h.bound), len(h.bound)+1)
+	copy(newBound, h.bound)
+	newBound = append(newBound, boundAttrs{groups: h.groups, attrs: attrs})
+
+	return &Handler{w: h.w, facility: h.facility, hostname: h.hostname, level: h.level, groups: h.groups, bound: newBound}
+}
+
+// WithGroup returns a new Handler that prefixes subsequent attribute keys
+// with name.
+func (h *Handler) WithGroup(name string) slog.Handler {
+	newGroups := make([]string, 0, len(h.groups)+1)
+	newGroups = append(newGroups, h.groups...)
+	newGroups = append(newGroups, name)
+	return &Handler{w: h.w, facility: h.facility, hostname: h.hostname, level: h.level, groups: newGroups, bound: h.bound}
+}
+
+// addAttr flattens a into extra under a GELF additional-field key made of
+// groups and a's own key, joined with dots and prefixed with an
+// underscore. Nested groups are flattened recursively; a group with an
+// empty key is inlined into its parent rather than adding a path
+// segment, per the log/slog.Handler contract.
+func addAttr(extra map[string]interface{}, groups []string, a slog.Attr) {
+	a.Value = a.Value.Resolve()
+	if a.Value.Kind() == slog.KindGroup {
+		nested := groups
+		if a.Key != "" {
+			nested = make([]string, 0, len(groups)+1)
+			nested = append(nested, groups...)
+			nested = append(nested, a.Key)
+		}
+		for _, ga := range a.Value.Group() {
+			addAttr(extra, nested, ga)
+		}
+		return
+	}
+
+	key := a.Key
+	if len(groups) > 0 {
+		key = strings.Join(groups, ".") + "." + key
+	}
+	extra["_"+key] = a.Value.Any()
+}
+
+// slogLevelToSyslog maps a slog.Level to the nearest LOG_* syslog
+// severity.
+func slogLevelToSyslog(level slog.Level) int32 {
+	switch {
+	case level >= slog.LevelError:
+		return LOG_ERR
+	case level >= slog.LevelWarn:
+		return LOG_WARNING
+	case level >= slog.LevelInfo:
+		return LOG_INFO
+	default:
+		return LOG_DEBUG
+	}
+}
@@ -0,0 +1,146 @@
+package gelf
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// captureWriter is a Writer that records every Message passed to
+// WriteMessage, for use in tests. It's safe for concurrent use so it can
+// back an AsyncWriter, whose background goroutine calls WriteMessage
+// independently of the test goroutine.
+type captureWriter struct {
+	mu       sync.Mutex
+	messages []*Message
+}
+
+func (c *captureWriter) Write(p []byte) (int, error) { return len(p), nil }
+
+func (c *captureWriter) WriteMessage(m *Message) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.messages = append(c.messages, m)
+	return nil
+}
+
+// messageSnapshot returns a copy of the messages recorded so far.
+func (c *captureWriter) messageSnapshot() []*Message {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]*Message(nil), c.messages...)
+}
+
+func (c *captureWriter) Close() error { return nil }
+
+// TestHandlerFlattensGroupedAttrs checks that WithGroup/With attrs and
+// per-record attrs are flattened into dotted, underscore-prefixed Extra
+// keys.
+func TestHandlerFlattensGroupedAttrs(t *testing.T) {
+	cw := &captureWriter{}
+	h := NewHandler(cw, "myapp", "myhost", slog.LevelInfo)
+
+	logger := slog.New(h).WithGroup("request").With(slog.String("id", "abc123"))
+	logger.Info("handled", slog.Int("status", 200))
+
+	if len(cw.messages) != 1 {
+		t.Fatalf("got %d messages, want 1", len(cw.messages))
+	}
+	m := cw.messages[0]
+
+	if m.Short != "handled" {
+		t.Errorf("Short = %q, want %q", m.Short, "handled")
+	}
+	if got, want := m.Extra["_request.id"], "abc123"; got != want {
+		t.Errorf("Extra[_request.id] = %v, want %v", got, want)
+	}
+	if got, want := m.Extra["_request.status"], int64(200); got != want {
+		t.Errorf("Extra[_request.status] = %v, want %v", got, want)
+	}
+}
+
+// TestHandlerAttrsBindToGroupAtCallTime checks that attrs bound via
+// With/WithAttrs are flattened using the group path that was active at
+// the time of the call, not the Handler's group path at Handle time.
+func TestHandlerAttrsBindToGroupAtCallTime(t *testing.T) {
+	cw := &captureWriter{}
+	h := NewHandler(cw, "myapp", "myhost", slog.LevelInfo)
+
+	// "id" is bound before WithGroup("request"), so it must stay
+	// ungrouped; only the record attr "status" is nested.
+	logger := slog.New(h).With(slog.String("id", "abc123")).WithGroup("request")
+	logger.Info("handled", slog.Int("status", 200))
+
+	if len(cw.messages) != 1 {
+		t.Fatalf("got %d messages, want 1", len(cw.messages))
+	}
+	m := cw.messages[0]
+
+	if got, want := m.Extra["_id"], "abc123"; got != want {
+		t.Errorf("Extra[_id] = %v, want %v", got, want)
+	}
+	if _, ok := m.Extra["_request.id"]; ok {
+		t.Errorf("Extra[_request.id] should not be set, \"id\" was bound before WithGroup")
+	}
+	if got, want := m.Extra["_request.status"], int64(200); got != want {
+		t.Errorf("Extra[_request.status] = %v, want %v", got, want)
+	}
+}
+
+// TestHandlerInlinesEmptyGroup checks that slog.Group("", attrs...)
+// inlines its attrs into the current prefix instead of adding a stray
+// "." segment.
+func TestHandlerInlinesEmptyGroup(t *testing.T) {
+	cw := &captureWriter{}
+	h := NewHandler(cw, "myapp", "myhost", slog.LevelInfo)
+
+	slog.New(h).Info("handled", slog.Group("", slog.String("inlined", "yes")))
+
+	if len(cw.messages) != 1 {
+		t.Fatalf("got %d messages, want 1", len(cw.messages))
+	}
+	m := cw.messages[0]
+
+	if got, want := m.Extra["_inlined"], "yes"; got != want {
+		t.Errorf("Extra[_inlined] = %v, want %v", got, want)
+	}
+	for k := range m.Extra {
+		if strings.Contains(k, "..") || strings.HasPrefix(k, "_.") {
+			t.Errorf("Extra has malformed key %q", k)
+		}
+	}
+}
+
+// TestSlogLevelToSyslog checks the slog.Level -> LOG_* mapping.
+func TestSlogLevelToSyslog(t *testing.T) {
+	cases := []struct {
+		level slog.Level
+		want  int32
+	}{
+		{slog.LevelDebug, LOG_DEBUG},
+		{slog.LevelInfo, LOG_INFO},
+		{slog.LevelWarn, LOG_WARNING},
+		{slog.LevelError, LOG_ERR},
+	}
+	for _, tc := range cases {
+		if got := slogLevelToSyslog(tc.level); got != tc.want {
+			t.Errorf("slogLevelToSyslog(%v) = %d, want %d", tc.level, got, tc.want)
+		}
+	}
+}
+
+// TestHandlerEnabled checks that Enabled respects the Handler's
+// configured level.
+func TestHandlerEnabled(t *testing.T) {
+	cw := &captureWriter{}
+	h := NewHandler(cw, "myapp", "myhost", slog.LevelWarn)
+
+	if h.Enabled(context.Background(), slog.LevelInfo) {
+		t.Error("Enabled(LevelInfo) = true, want false when handler level is Warn")
+	}
+	if !h.Enabled(context.Background(), slog.LevelError) {
+		t.Error("Enabled(LevelError) = false, want true")
+	}
+}
@@ -0,0 +1,382 @@
+package gelf
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"crypto/rand"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"runtime"
+	"sync"
+	"time"
+)
+
+// CompressionType selects the compression applied to UDP GELF messages
+// before they are sent.
+type CompressionType int
+
+const (
+	CompressGzip CompressionType = iota
+	CompressZlib
+	CompressNone
+)
+
+const (
+	// chunkMagicByte0 and chunkMagicByte1 prefix every GELF UDP chunk, per
+	// the chunked protocol used by the Graylog server.
+	chunkMagicByte0 = 0x1e
+	chunkMagicByte1 = 0x0f
+
+	// chunkHeaderLen is made up of the 2 magic bytes, the 8-byte message
+	// ID, a 1-byte sequence number and a 1-byte sequence count.
+	chunkHeaderLen = 12
+
+	// maxChunks is the maximum number of chunks a single GELF message may
+	// be split into.
+	maxChunks = 128
+
+	// defaultChunkSize is the default number of bytes sent in a single
+	// UDP chunk payload.
+	defaultChunkSize = 8192
+
+	// DefaultMaxMessageSize is the default ceiling on a compressed
+	// message's size: the most that maxChunks chunks of defaultChunkSize
+	// bytes can carry.
+	DefaultMaxMessageSize = defaultChunkSize * maxChunks
+
+	defaultMaxReconnect   = 5
+	defaultReconnectDelay = 1 * time.Second
+)
+
+// Writer is implemented by the UDP and TCP GELF writers.
+type Writer interface {
+	io.Writer
+
+	// WriteMessage sends a fully constructed Message.
+	WriteMessage(m *Message) error
+
+	Close() error
+}
+
+// hostFacilitier is implemented by Writers that stamp a hostname and
+// facility on the messages they build from raw bytes in Write. Writer
+// wrappers (e.g. AsyncWriter) that also need to build such messages can
+// type-assert their inner Writer against this interface to reuse them.
+type hostFacilitier interface {
+	hostFacility() (hostname, facility string)
+}
+
+// UDPWriter sends GELF messages to a Graylog server over UDP, splitting
+// messages that don't fit in a single datagram using the chunked GELF
+// protocol.
+type UDPWriter struct {
+	conn     *net.UDPConn
+	Hostname string
+	Facility string
+
+	// CompressionType is the compression applied to the serialized
+	// message before it is (possibly) chunked and sent. Defaults to
+	// CompressGzip.
+	CompressionType CompressionType
+
+	// CompressionLevel is passed to the selected compressor; see
+	// compress/flate for the meaning of the values.
+	CompressionLevel int
+
+	// ChunkSize is the maximum number of payload bytes per UDP chunk.
+	// Defaults to defaultChunkSize.
+	ChunkSize int
+
+	// MaxMessageSize is the largest compressed message WriteMessage will
+	// send, oversized messages are rejected rather than silently
+	// truncated by the chunking protocol's 128-chunk ceiling. Defaults to
+	// DefaultMaxMessageSize.
+	MaxMessageSize int
+}
+
+// MessageTooLargeError is returned by UDPWriter.WriteMessage when a
+// compressed message exceeds MaxMessageSize.
+type MessageTooLargeError struct {
+	Size, MaxSize int
+}
+
+func (e *MessageTooLargeError) Error() string {
+	return fmt.Sprintf("gelf: compressed message is %d bytes, exceeds MaxMessageSize of %d", e.Size, e.MaxSize)
+}
+
+// NewUDPWriter returns a new UDPWriter that sends messages to addr.
+func NewUDPWriter(addr string) (*UDPWriter, error) {
+	udpAddr, err := net.ResolveUDPAddr("udp", addr)
+	if err != nil {
+		return nil, err
+	}
+	conn, err := net.DialUDP("udp", nil, udpAddr)
+	if err != nil {
+		return nil, err
+	}
+
+	hostname, err := os.Hostname()
+	if err != nil {
+		hostname = "localhost"
+	}
+
+	return &UDPWriter{
+		conn:             conn,
+		Hostname:         hostname,
+		CompressionType:  CompressGzip,
+		CompressionLevel: flate.BestSpeed,
+		ChunkSize:        defaultChunkSize,
+		MaxMessageSize:   DefaultMaxMessageSize,
+	}, nil
+}
+
+// hostFacility implements hostFacilitier.
+func (w *UDPWriter) hostFacility() (hostname, facility string) {
+	return w.Hostname, w.Facility
+}
+
+// Write implements io.Writer, constructing a Message from p the same way
+// the standard library's log package would and sending it.
+func (w *UDPWriter) Write(p []byte) (int, error) {
+	_, file, line, ok := runtime.Caller(1)
+	if !ok {
+		file = ""
+		line = 0
+	}
+	m := constructMessage(p, w.Hostname, w.Facility, file, line)
+	if err := w.WriteMessage(m); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// WriteMessage serializes, compresses and sends m, chunking it if it
+// doesn't fit in a single UDP datagram.
+func (w *UDPWriter) WriteMessage(m *Message) error {
+	var buf bytes.Buffer
+	messageBytes, err := m.toBytes(&buf)
+	if err != nil {
+		return err
+	}
+
+	compressed, err := compress(messageBytes, w.CompressionType, w.CompressionLevel)
+	if err != nil {
+		return err
+	}
+
+	maxMessageSize := w.MaxMessageSize
+	if maxMessageSize <= 0 {
+		maxMessageSize = DefaultMaxMessageSize
+	}
+	if len(compressed) > maxMessageSize {
+		return &MessageTooLargeError{Size: len(compressed), MaxSize: maxMessageSize}
+	}
+
+	chunkSize := w.ChunkSize
+	if chunkSize <= 0 {
+		chunkSize = defaultChunkSize
+	}
+
+	if len(compressed) <= chunkSize {
+		_, err = w.conn.Write(compressed)
+		return err
+	}
+
+	return writeChunked(w.conn, compressed, chunkSize)
+}
+
+// Close closes the underlying UDP connection.
+func (w *UDPWriter) Close() error {
+	return w.conn.Close()
+}
+
+// writeChunked splits payload into GELF chunks of at most chunkSize bytes
+// each and writes them one at a time to conn.
+func writeChunked(conn io.Writer, payload []byte, chunkSize int) error {
+	numChunks := (len(payload) + chunkSize - 1) / chunkSize
+	if numChunks > maxChunks {
+		return fmt.Errorf("gelf: message too large: would require %d chunks, max is %d", numChunks, maxChunks)
+	}
+
+	msgID := make([]byte, 8)
+	if _, err := rand.Read(msgID); err != nil {
+		return err
+	}
+
+	for i := 0; i < numChunks; i++ {
+		start := i * chunkSize
+		end := start + chunkSize
+		if end > len(payload) {
+			end = len(payload)
+		}
+
+		chunk := make([]byte, 0, chunkHeaderLen+end-start)
+		chunk = append(chunk, chunkMagicByte0, chunkMagicByte1)
+		chunk = append(chunk, msgID...)
+		chunk = append(chunk, byte(i), byte(numChunks))
+		chunk = append(chunk, payload[start:end]...)
+
+		if _, err := conn.Write(chunk); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// compress compresses data using the given compression type. CompressNone
+// returns data unchanged.
+func compress(data []byte, typ CompressionType, level int) ([]byte, error) {
+	switch typ {
+	case CompressNone:
+		return data, nil
+	case CompressZlib:
+		var buf bytes.Buffer
+		w, err := zlib.NewWriterLevel(&buf, level)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := w.Write(data); err != nil {
+			return nil, err
+		}
+		if err := w.Close(); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	default:
+		var buf bytes.Buffer
+		w, err := gzip.NewWriterLevel(&buf, level)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := w.Write(data); err != nil {
+			return nil, err
+		}
+		if err := w.Close(); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	}
+}
+
+// TCPWriter sends newline-delimited, uncompressed GELF JSON messages to a
+// Graylog server over TCP, reconnecting on write failure.
+type TCPWriter struct {
+	addr string
+
+	// mu guards conn, which is read, closed and reassigned whenever
+	// WriteMessage reconnects, so that WriteMessage is safe to call
+	// concurrently (as slog.Handler requires of the Writer it wraps).
+	mu   sync.Mutex
+	conn net.Conn
+
+	Hostname string
+	Facility string
+
+	// MaxReconnect is the number of times to retry dialing addr after a
+	// write fails. Defaults to defaultMaxReconnect.
+	MaxReconnect int
+
+	// ReconnectDelay is the delay between reconnect attempts. Defaults to
+	// defaultReconnectDelay.
+	ReconnectDelay time.Duration
+}
+
+// NewTCPWriter returns a new TCPWriter that sends messages to addr.
+func NewTCPWriter(addr string) (*TCPWriter, error) {
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+
+	hostname, err := os.Hostname()
+	if err != nil {
+		hostname = "localhost"
+	}
+
+	return &TCPWriter{
+		addr:           addr,
+		conn:           conn,
+		Hostname:       hostname,
+		MaxReconnect:   defaultMaxReconnect,
+		ReconnectDelay: defaultReconnectDelay,
+	}, nil
+}
+
+// hostFacility implements hostFacilitier.
+func (w *TCPWriter) hostFacility() (hostname, facility string) {
+	return w.Hostname, w.Facility
+}
+
+// Write implements io.Writer, constructing a Message from p the same way
+// the standard library's log package would and sending it.
+func (w *TCPWriter) Write(p []byte) (int, error) {
+	_, file, line, ok := runtime.Caller(1)
+	if !ok {
+		file = ""
+		line = 0
+	}
+	m := constructMessage(p, w.Hostname, w.Facility, file, line)
+	if err := w.WriteMessage(m); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// WriteMessage serializes m as uncompressed, newline-terminated JSON and
+// sends it, reconnecting according to MaxReconnect/ReconnectDelay if the
+// write fails.
+func (w *TCPWriter) WriteMessage(m *Message) error {
+	var buf bytes.Buffer
+	messageBytes, err := m.toBytes(&buf)
+	if err != nil {
+		return err
+	}
+	messageBytes = append(messageBytes, '\n')
+
+	maxReconnect := w.MaxReconnect
+	if maxReconnect <= 0 {
+		maxReconnect = defaultMaxReconnect
+	}
+	reconnectDelay := w.ReconnectDelay
+	if reconnectDelay <= 0 {
+		reconnectDelay = defaultReconnectDelay
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	var lastErr error
+	for attempt := 0; attempt <= maxReconnect; attempt++ {
+		if attempt > 0 {
+			time.Sleep(reconnectDelay)
+			conn, dialErr := net.Dial("tcp", w.addr)
+			if dialErr != nil {
+				lastErr = dialErr
+				continue
+			}
+			if w.conn != nil {
+				w.conn.Close()
+			}
+			w.conn = conn
+		}
+
+		if _, err := w.conn.Write(messageBytes); err != nil {
+			lastErr = err
+			continue
+		}
+		return nil
+	}
+
+	return fmt.Errorf("gelf: failed to write message after %d attempts: %w", maxReconnect+1, lastErr)
+}
+
+// Close closes the underlying TCP connection.
+func (w *TCPWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.conn.Close()
+}
@@ -0,0 +1,238 @@
+package gelf
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// TestTCPWriterConcurrentWriteMessage exercises WriteMessage from many
+// goroutines at once over a live connection; run with -race to confirm
+// that concurrent writes to the shared connection are properly
+// synchronized.
+func TestTCPWriterConcurrentWriteMessage(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	defer ln.Close()
+
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go discardConn(conn)
+		}
+	}()
+
+	w, err := NewTCPWriter(ln.Addr().String())
+	if err != nil {
+		t.Fatalf("NewTCPWriter: %v", err)
+	}
+	defer w.Close()
+
+	const goroutines = 16
+	var wg sync.WaitGroup
+	wg.Add(goroutines)
+	for i := 0; i < goroutines; i++ {
+		go func(i int) {
+			defer wg.Done()
+			m := constructMessageFromString("concurrent write", LOG_INFO, nil)
+			if err := w.WriteMessage(m); err != nil {
+				t.Errorf("WriteMessage: %v", err)
+			}
+		}(i)
+	}
+	wg.Wait()
+}
+
+// discardConn reads and discards everything from conn until it's closed.
+func discardConn(conn net.Conn) {
+	defer conn.Close()
+	buf := make([]byte, 4096)
+	for {
+		if _, err := conn.Read(buf); err != nil {
+			return
+		}
+	}
+}
+
+// TestTCPWriterReconnectsOnWriteFailure checks that WriteMessage redials
+// and succeeds after the live connection is dropped out from under it.
+func TestTCPWriterReconnectsOnWriteFailure(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen: %v", err)
+	}
+	defer ln.Close()
+
+	accepted := make(chan net.Conn, 4)
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			accepted <- conn
+			go discardConn(conn)
+		}
+	}()
+
+	w, err := NewTCPWriter(ln.Addr().String())
+	if err != nil {
+		t.Fatalf("NewTCPWriter: %v", err)
+	}
+	defer w.Close()
+	w.MaxReconnect = 3
+	w.ReconnectDelay = 10 * time.Millisecond
+
+	first := <-accepted
+
+	if err := w.WriteMessage(constructMessageFromString("before drop", LOG_INFO, nil)); err != nil {
+		t.Fatalf("WriteMessage before drop: %v", err)
+	}
+
+	// Abort the connection (RST) rather than closing it cleanly, so the
+	// next client write fails immediately instead of succeeding into the
+	// OS send buffer.
+	if tc, ok := first.(*net.TCPConn); ok {
+		tc.SetLinger(0)
+	}
+	first.Close()
+	time.Sleep(10 * time.Millisecond)
+
+	if err := w.WriteMessage(constructMessageFromString("after drop", LOG_INFO, nil)); err != nil {
+		t.Fatalf("WriteMessage after drop: %v, want it to reconnect and succeed", err)
+	}
+
+	select {
+	case <-accepted:
+	case <-time.After(time.Second):
+		t.Fatal("writer never redialed after the connection was dropped")
+	}
+}
+
+// TestTCPWriterGivesUpAfterMaxReconnect checks that WriteMessage returns
+// a wrapped error once redialing has failed MaxReconnect times in a row.
+func TestTCPWriterGivesUpAfterMaxReconnect(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen: %v", err)
+	}
+
+	accepted := make(chan net.Conn, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		accepted <- conn
+	}()
+
+	w, err := NewTCPWriter(ln.Addr().String())
+	if err != nil {
+		t.Fatalf("NewTCPWriter: %v", err)
+	}
+	defer w.Close()
+	w.MaxReconnect = 2
+	w.ReconnectDelay = 5 * time.Millisecond
+
+	serverConn := <-accepted
+
+	if err := w.WriteMessage(constructMessageFromString("before drop", LOG_INFO, nil)); err != nil {
+		t.Fatalf("WriteMessage before drop: %v", err)
+	}
+
+	if tc, ok := serverConn.(*net.TCPConn); ok {
+		tc.SetLinger(0)
+	}
+	serverConn.Close()
+	ln.Close() // No further connections can be accepted, so every redial fails.
+	time.Sleep(10 * time.Millisecond)
+
+	err = w.WriteMessage(constructMessageFromString("after drop", LOG_INFO, nil))
+	if err == nil {
+		t.Fatal("WriteMessage: expected an error after exhausting MaxReconnect, got nil")
+	}
+	wantSub := fmt.Sprintf("failed to write message after %d attempts", w.MaxReconnect+1)
+	if !strings.Contains(err.Error(), wantSub) {
+		t.Fatalf("error = %q, want substring %q", err.Error(), wantSub)
+	}
+}
+
+// TestWriteChunkedSplitsPayload checks that writeChunked splits a
+// payload into the expected number of correctly framed chunks.
+func TestWriteChunkedSplitsPayload(t *testing.T) {
+	var chunks [][]byte
+	fakeConn := writerFunc(func(p []byte) (int, error) {
+		chunks = append(chunks, append([]byte(nil), p...))
+		return len(p), nil
+	})
+
+	payload := make([]byte, 25)
+	for i := range payload {
+		payload[i] = byte(i)
+	}
+
+	if err := writeChunked(fakeConn, payload, 10); err != nil {
+		t.Fatalf("writeChunked: %v", err)
+	}
+
+	if len(chunks) != 3 {
+		t.Fatalf("got %d chunks, want 3", len(chunks))
+	}
+
+	msgID := chunks[0][2:10]
+	for i, c := range chunks {
+		if c[0] != chunkMagicByte0 || c[1] != chunkMagicByte1 {
+			t.Fatalf("chunk %d: missing magic bytes", i)
+		}
+		if string(c[2:10]) != string(msgID) {
+			t.Fatalf("chunk %d: message ID mismatch", i)
+		}
+		if c[10] != byte(i) {
+			t.Fatalf("chunk %d: sequence number = %d, want %d", i, c[10], i)
+		}
+		if c[11] != 3 {
+			t.Fatalf("chunk %d: count = %d, want 3", i, c[11])
+		}
+	}
+}
+
+// TestUDPWriterEnforcesMaxMessageSize checks that WriteMessage rejects a
+// message whose compressed size exceeds MaxMessageSize instead of
+// silently truncating it via the chunking protocol's chunk-count ceiling.
+func TestUDPWriterEnforcesMaxMessageSize(t *testing.T) {
+	ln, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatalf("ListenUDP: %v", err)
+	}
+	defer ln.Close()
+
+	w, err := NewUDPWriter(ln.LocalAddr().String())
+	if err != nil {
+		t.Fatalf("NewUDPWriter: %v", err)
+	}
+	defer w.Close()
+
+	w.CompressionType = CompressNone
+	w.MaxMessageSize = 10
+
+	m := constructMessageFromString(randomString(1000), LOG_INFO, nil)
+	err = w.WriteMessage(m)
+
+	var tooLarge *MessageTooLargeError
+	if !errors.As(err, &tooLarge) {
+		t.Fatalf("WriteMessage error = %v, want *MessageTooLargeError", err)
+	}
+}
+
+type writerFunc func(p []byte) (int, error)
+
+func (f writerFunc) Write(p []byte) (int, error) { return f(p) }